@@ -0,0 +1,166 @@
+package main
+
+import "gonum.org/v1/gonum/mat"
+
+// Communicator performs the MPI-FAUN collective operations a node needs
+// each iteration: all-reduces over the whole p_r x p_c grid (for the Gram
+// matrices and the convergence check's scalars), and one all-gather plus
+// one reduce-scatter over each of
+// the node-row and node-column groups (for assembling W/H slices and their
+// products with A). Abstracting these behind an interface lets the same
+// parallelNMF loop run over in-process goroutines or real MPI ranks.
+type Communicator interface {
+	AllReduce(node *Node, local *mat.Dense) *mat.Dense
+	AllGatherAcrossNodeRows(node *Node, local *mat.Dense) *mat.Dense
+	AllGatherAcrossNodeColumns(node *Node, local *mat.Dense) *mat.Dense
+	ReduceScatterAcrossNodeRows(node *Node, local *mat.Dense) *mat.Dense
+	ReduceScatterAcrossNodeColumns(node *Node, local *mat.Dense) *mat.Dense
+}
+
+// ChannelCommunicator is the original in-process transport: nodes are
+// goroutines on one machine and collectives are implemented by passing
+// MatMessages over the per-node channels already wired up in makeNode.
+type ChannelCommunicator struct{}
+
+// rowGroup returns every nodeID sharing node's row index (rowIdx*numNodeCols
+// + 0..numNodeCols-1), ordered by ascending column index.
+func rowGroup(node *Node) []int {
+	base := node.rowIdx() * numNodeCols
+	ids := make([]int, numNodeCols)
+	for j := 0; j < numNodeCols; j++ {
+		ids[j] = base + j
+	}
+	return ids
+}
+
+// colGroup returns every nodeID sharing node's column index (colIdx,
+// colIdx+numNodeCols, ...), ordered by ascending row index.
+func colGroup(node *Node) []int {
+	col := node.colIdx()
+	ids := make([]int, numNodeRows)
+	for i := 0; i < numNodeRows; i++ {
+		ids[i] = i*numNodeCols + col
+	}
+	return ids
+}
+
+// broadcastTo sends local to every id in group other than node's own, all
+// tagged with seq, then collects and returns the same message from every
+// other member (node's own contribution is not included).
+func broadcastTo(node *Node, group []int, local *mat.Dense, seq int) []MatMessage {
+	for _, id := range group {
+		if id == node.nodeID {
+			continue
+		}
+		node.nodeChans[id] <- MatMessage{mtx: *local, sentID: node.nodeID, seq: seq}
+	}
+
+	received := make([]MatMessage, 0, len(group)-1)
+	for range group {
+		if len(received) == len(group)-1 {
+			break
+		}
+		received = append(received, node.receive(seq))
+	}
+	return received
+}
+
+// AllReduce sums local across every node in the grid and returns the total
+// to all of them. The pieces are added in nodeID order on every node, so
+// every node gets a bit-identical total.
+func (ChannelCommunicator) AllReduce(node *Node, local *mat.Dense) *mat.Dense {
+	seq := node.nextSeq()
+	all := make([]int, numNodes)
+	for i := range all {
+		all[i] = i
+	}
+
+	pieces := gatherOrdered(node, all, local, seq)
+	sum := mat.DenseCopyOf(pieces[0])
+	for _, piece := range pieces[1:] {
+		sum.Add(sum, piece)
+	}
+	return sum
+}
+
+// AllGatherAcrossNodeRows stacks the row-group's local pieces (ascending
+// column index) into one (m/p_r) x k matrix.
+func (ChannelCommunicator) AllGatherAcrossNodeRows(node *Node, local *mat.Dense) *mat.Dense {
+	seq := node.nextSeq()
+	group := rowGroup(node)
+	pieces := gatherOrdered(node, group, local, seq)
+
+	rows, cols := local.Dims()
+	out := mat.NewDense(rows*len(group), cols, nil)
+	for pos, piece := range pieces {
+		out.Slice(pos*rows, (pos+1)*rows, 0, cols).(*mat.Dense).Copy(piece)
+	}
+	return out
+}
+
+// AllGatherAcrossNodeColumns concatenates the column-group's local pieces
+// (ascending row index) into one k x (n/p_c) matrix.
+func (ChannelCommunicator) AllGatherAcrossNodeColumns(node *Node, local *mat.Dense) *mat.Dense {
+	seq := node.nextSeq()
+	group := colGroup(node)
+	pieces := gatherOrdered(node, group, local, seq)
+
+	rows, cols := local.Dims()
+	out := mat.NewDense(rows, cols*len(group), nil)
+	for pos, piece := range pieces {
+		out.Slice(0, rows, pos*cols, (pos+1)*cols).(*mat.Dense).Copy(piece)
+	}
+	return out
+}
+
+// gatherOrdered returns every group member's contribution to `local`,
+// ordered to match `group`'s order, with node's own slotted in directly.
+func gatherOrdered(node *Node, group []int, local *mat.Dense, seq int) []*mat.Dense {
+	byID := map[int]*mat.Dense{node.nodeID: local}
+	for _, msg := range broadcastTo(node, group, local, seq) {
+		byID[msg.sentID] = &msg.mtx
+	}
+
+	pieces := make([]*mat.Dense, len(group))
+	for pos, id := range group {
+		pieces[pos] = byID[id]
+	}
+	return pieces
+}
+
+// ReduceScatterAcrossNodeRows sums the row-group's (m/p_r) x k contributions
+// and hands each member the row-slice of the total matching its column
+// index, undoing the split AllGatherAcrossNodeRows performed.
+func (c ChannelCommunicator) ReduceScatterAcrossNodeRows(node *Node, local *mat.Dense) *mat.Dense {
+	seq := node.nextSeq()
+	group := rowGroup(node)
+	sum := reduceGroup(node, group, local, seq)
+
+	rows, cols := sum.Dims()
+	chunkRows := rows / len(group)
+	pos := node.colIdx()
+	return mat.DenseCopyOf(sum.Slice(pos*chunkRows, (pos+1)*chunkRows, 0, cols))
+}
+
+// ReduceScatterAcrossNodeColumns sums the column-group's k x (n/p_c)
+// contributions and hands each member the column-slice of the total
+// matching its row index, undoing the split AllGatherAcrossNodeColumns
+// performed.
+func (c ChannelCommunicator) ReduceScatterAcrossNodeColumns(node *Node, local *mat.Dense) *mat.Dense {
+	seq := node.nextSeq()
+	group := colGroup(node)
+	sum := reduceGroup(node, group, local, seq)
+
+	rows, cols := sum.Dims()
+	chunkCols := cols / len(group)
+	pos := node.rowIdx()
+	return mat.DenseCopyOf(sum.Slice(0, rows, pos*chunkCols, (pos+1)*chunkCols))
+}
+
+func reduceGroup(node *Node, group []int, local *mat.Dense, seq int) *mat.Dense {
+	sum := mat.DenseCopyOf(local)
+	for _, msg := range broadcastTo(node, group, local, seq) {
+		sum.Add(sum, &msg.mtx)
+	}
+	return sum
+}
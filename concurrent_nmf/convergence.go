@@ -0,0 +1,101 @@
+package main
+
+import (
+	"math"
+
+	"gonum.org/v1/gonum/mat"
+)
+
+// StopCriterion bounds how long parallelNMF runs: it keeps iterating while
+// the relative Frobenius residual ||A - WH||_F / ||A||_F is still falling,
+// and stops as soon as it stalls, instead of always running a fixed number
+// of iterations regardless of whether the factorization has converged.
+type StopCriterion struct {
+	Tol      float64 // an iteration counts as an improvement only if it beats the best residual by more than Tol
+	MaxIter  int     // hard cap, always respected
+	MinIter  int     // always run at least this many iterations before allowed to stop early
+	Patience int     // iterations without improvement before stopping early
+}
+
+// DefaultStopCriterion matches the historical fixed 100-iteration behavior
+// as a ceiling, while still exiting early once the residual stalls.
+func DefaultStopCriterion() StopCriterion {
+	return StopCriterion{Tol: 1e-4, MaxIter: 100, MinIter: 5, Patience: 5}
+}
+
+// convergenceTracker holds the patience/tolerance bookkeeping for a
+// StopCriterion across iterations. Every node computes the same residual
+// (it's assembled from already all-reduced matrices), so each node's
+// tracker reaches the same verdict independently with no extra
+// synchronization.
+type convergenceTracker struct {
+	stop         StopCriterion
+	best         float64
+	sinceImprove int
+}
+
+func newConvergenceTracker(stop StopCriterion) *convergenceTracker {
+	return &convergenceTracker{stop: stop, best: math.Inf(1)}
+}
+
+// observe records the residual for the iteration that just finished (0
+// indexed) and reports whether parallelNMF should stop after it.
+func (c *convergenceTracker) observe(iter int, residual float64) bool {
+	if residual < c.best-c.stop.Tol {
+		c.best = residual
+		c.sinceImprove = 0
+	} else {
+		c.sinceImprove++
+	}
+
+	if iter+1 < c.stop.MinIter {
+		return false
+	}
+	if iter+1 >= c.stop.MaxIter {
+		return true
+	}
+	return c.sinceImprove >= c.stop.Patience
+}
+
+// frobeniusResidual computes the relative residual ||A - WH||_F / ||A||_F
+// via ||A - WH||_F^2 = ||A||_F^2 - 2*tr(W^T A H^T) + tr(W^T W * H H^T),
+// using quantities parallelNMF already has after steps 10 and 13: the new
+// WGramMat (= W^T W) and the old HGramMat (= H H^T), which are already
+// all-reduced over the whole grid so every node holds the same value;
+// crossTerm (= tr(W^T A H^T), see localCrossTerm) must likewise be the
+// grid-wide sum, not just this node's slice, or nodes will observe
+// different residuals and make independent stop decisions; and normSqA
+// (the precomputed, all-reduced ||A||_F^2).
+func frobeniusResidual(normSqA float64, WGramMat, HGramMat *mat.Dense, crossTerm float64) float64 {
+	if normSqA == 0 {
+		return 0
+	}
+
+	gramTerm := traceOfProduct(WGramMat, HGramMat) // tr(WGramMat * HGramMat^T)
+
+	residualSq := normSqA - 2*crossTerm + gramTerm
+	if residualSq < 0 {
+		residualSq = 0 // floating-point cancellation near convergence
+	}
+	return math.Sqrt(residualSq / normSqA)
+}
+
+// localCrossTerm computes this node's contribution tr(WProductMatji *
+// Hji^T) to the grid-wide tr(W^T A H^T); callers must all-reduce it across
+// the whole grid (it is only a partial sum over this node's column slice of
+// H) before passing it to frobeniusResidual.
+func localCrossTerm(WProductMatji mat.Matrix, Hji *mat.Dense) float64 {
+	return traceOfProduct(WProductMatji, Hji)
+}
+
+// traceOfProduct computes tr(A * B^T) = sum_ij A_ij*B_ij for same-shaped A, B.
+func traceOfProduct(A, B mat.Matrix) float64 {
+	rows, cols := A.Dims()
+	sum := 0.0
+	for i := 0; i < rows; i++ {
+		for j := 0; j < cols; j++ {
+			sum += A.At(i, j) * B.At(i, j)
+		}
+	}
+	return sum
+}
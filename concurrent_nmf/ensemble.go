@@ -0,0 +1,179 @@
+package main
+
+import (
+	"sync"
+
+	"gonum.org/v1/gonum/mat"
+)
+
+// NodeEnsemble owns the node set for one grid shape and knows how to
+// collect the nodes' current iterate and rebuild the ensemble on a new
+// p_r x p_c grid. NMF's best grid shape depends on the k/m/n ratio, which
+// shifts character across the run (communication-bound early on,
+// compute-bound later), so Reconfigure lets a caller (or a policy watching
+// the diagnostic channel from parallelNMF) resize the grid between runs
+// instead of being stuck with the shape it started with.
+type NodeEnsemble struct {
+	A        *mat.Dense // dense input; nil if triplets is set
+	triplets []Triplet  // sparse (coordinate-form) input; nil if A is set
+	updater  NMFUpdater
+	Reg      Regularizer // optional L1/L2/orthogonality penalty applied every Run; zero value is unregularized
+	comm     Communicator
+
+	nodes       []*Node
+	clientChan  chan MatMessage
+	pauseChan   chan struct{}
+	runFinished chan struct{} // closed by Run once e.W/e.H are safe to read
+
+	// W, H hold the latest global iterate once Run has returned; nil until
+	// the first Run call finishes or pauses.
+	W, H *mat.Dense
+}
+
+// NewNodeEnsemble partitions A onto a rows x cols grid and builds the node
+// set for it, with a random initial iterate on the first Run.
+func NewNodeEnsemble(A *mat.Dense, rows, cols int, updater NMFUpdater, comm Communicator) *NodeEnsemble {
+	e := &NodeEnsemble{A: A, updater: updater, comm: comm}
+	e.rebuildNodes(rows, cols)
+	return e
+}
+
+// NewSparseNodeEnsemble is NewNodeEnsemble for an A supplied as coordinate
+// (triplet) form rather than a dense matrix, so a node's share of A is
+// CSR tiles (see tile.go) and is never materialized as a dense m x n block
+// of floats.
+func NewSparseNodeEnsemble(triplets []Triplet, rows, cols int, updater NMFUpdater, comm Communicator) *NodeEnsemble {
+	e := &NodeEnsemble{triplets: triplets, updater: updater, comm: comm}
+	e.rebuildNodes(rows, cols)
+	return e
+}
+
+// rebuildNodes sets the grid shape, repartitions A (dense or sparse,
+// whichever e was built with), and constructs a fresh set of nodes and
+// channels for it.
+func (e *NodeEnsemble) rebuildNodes(rows, cols int) {
+	numNodeRows, numNodeCols = rows, cols
+	recomputeBlockSizes()
+
+	chans := makeMatrixChans()
+	akChans := makeAkChans()
+	e.clientChan = make(chan MatMessage, numNodes*3)
+	e.pauseChan = make(chan struct{})
+	e.runFinished = make(chan struct{})
+
+	var pieces []LocalTile
+	if e.triplets != nil {
+		pieces = partitionSparseAMatrix(e.triplets, numNodeRows, numNodeCols, largeBlockSizeW, largeBlockSizeH)
+	} else {
+		pieces = partitionAMatrix(e.A)
+	}
+	e.nodes = make([]*Node, numNodes)
+	for i := range e.nodes {
+		e.nodes[i] = makeNode(chans, akChans, e.clientChan, i, pieces[i], e.comm)
+	}
+}
+
+// Run launches every node in the current ensemble and blocks until they
+// either satisfy stop or are paused by a call to Reconfigure, updating
+// e.W/e.H with whichever iterate they stopped at. diagChan is passed
+// through to every node unchanged (see parallelNMF).
+func (e *NodeEnsemble) Run(stop StopCriterion, diagChan chan<- float64) {
+	// Snapshot the channels/pause signal for this grid shape up front: if a
+	// concurrent Reconfigure call swaps e.clientChan/e.pauseChan out from
+	// under us mid-run, this Run call still drains the grid it actually
+	// launched rather than the ensemble's replacement.
+	nodes := e.nodes
+	clientChan := e.clientChan
+	pauseChan := e.pauseChan
+	finished := e.runFinished
+	total := len(nodes)
+
+	wPieces, hPieces := make([]*mat.Dense, total), make([]*mat.Dense, total)
+	initW, initH := partitionIterate(e.W, e.H)
+
+	var runWg sync.WaitGroup
+	for _, node := range nodes {
+		runWg.Add(1)
+		node := node
+		go parallelNMF(node, stop, e.updater, e.Reg, diagChan, pauseChan, initW[node.nodeID], initH[node.nodeID], &runWg)
+	}
+
+	for w, h := 0, 0; w < total || h < total; {
+		next := <-clientChan
+		if next.isFinalW {
+			wPieces[next.sentID] = &next.mtx
+			w++
+		} else if next.isFinalH {
+			hPieces[next.sentID] = &next.mtx
+			h++
+		}
+	}
+	runWg.Wait()
+
+	e.W = assembleW(wPieces)
+	e.H = assembleH(hPieces)
+	close(finished)
+}
+
+// Reconfigure pauses the ensemble at its next iteration boundary, gathers
+// the current (W, H) iterate via the same clientChan path normal
+// completion uses, repartitions A and (W, H) onto a newRows x newCols
+// grid, and rebuilds the node set so the next Run resumes from there. It
+// blocks until the in-flight Run call (invoked separately, typically from
+// the goroutine that started the ensemble) has actually finished collecting
+// that iterate, so the caller can always call Run again immediately after
+// Reconfigure returns.
+func (e *NodeEnsemble) Reconfigure(newRows, newCols int) {
+	finished := e.runFinished
+	close(e.pauseChan)
+	<-finished
+
+	e.rebuildNodes(newRows, newCols)
+}
+
+// partitionIterate slices the current global (W, H) iterate into the
+// per-node Wij/Hji pieces the current grid shape expects. Either matrix may
+// be nil (first run), in which case every piece is nil and parallelNMF
+// falls back to a random start.
+func partitionIterate(W, H *mat.Dense) (wPieces, hPieces []*mat.Dense) {
+	wPieces = make([]*mat.Dense, numNodes)
+	hPieces = make([]*mat.Dense, numNodes)
+	if W != nil {
+		for i := 0; i < numNodes; i++ {
+			wPieces[i] = mat.DenseCopyOf(W.Slice(i*smallBlockSizeW, (i+1)*smallBlockSizeW, 0, k))
+		}
+	}
+	if H != nil {
+		for i := 0; i < numNodes; i++ {
+			hPieces[i] = mat.DenseCopyOf(H.Slice(0, k, i*smallBlockSizeH, (i+1)*smallBlockSizeH))
+		}
+	}
+	return wPieces, hPieces
+}
+
+// assembleW and assembleH mirror the slicing partitionIterate undoes,
+// stitching each node's final Wij/Hji piece back into the global W, H
+// (the same layout main's original inline collection loop used).
+func assembleW(wPieces []*mat.Dense) *mat.Dense {
+	w := make([]float64, m*k)
+	for i := 0; i < numNodes; i++ {
+		for j := 0; j < smallBlockSizeW; j++ {
+			for l := 0; l < k; l++ {
+				w[(i*smallBlockSizeW*k)+(j*k)+l] = wPieces[i].At(j, l)
+			}
+		}
+	}
+	return mat.NewDense(m, k, w)
+}
+
+func assembleH(hPieces []*mat.Dense) *mat.Dense {
+	h := make([]float64, k*n)
+	for j := 0; j < k; j++ {
+		for i := 0; i < numNodes; i++ {
+			for l := 0; l < smallBlockSizeH; l++ {
+				h[(j*numNodes*smallBlockSizeH)+(i*smallBlockSizeH)+l] = hPieces[i].At(j, l)
+			}
+		}
+	}
+	return mat.NewDense(k, n, h)
+}
@@ -0,0 +1,99 @@
+package main
+
+import (
+	"math/rand"
+	"testing"
+	"time"
+
+	"gonum.org/v1/gonum/mat"
+)
+
+// TestNodeEnsembleRunDoesNotPanic guards against Run's runWg/parallelNMF's
+// wg getting out of sync - parallelNMF used to call a hardcoded package
+// wg.Done() that Run never Add()'d to, which panicked with "sync: negative
+// WaitGroup counter" on the very first node to finish.
+func TestNodeEnsembleRunDoesNotPanic(t *testing.T) {
+	origRows, origCols := numNodeRows, numNodeCols
+	defer func() {
+		numNodeRows, numNodeCols = origRows, origCols
+		recomputeBlockSizes()
+	}()
+
+	rand.Seed(3)
+	a := make([]float64, m*n)
+	for i := range a {
+		a[i] = rand.Float64()
+	}
+	A := mat.NewDense(m, n, a)
+
+	e := NewNodeEnsemble(A, 2, 2, MultiplicativeUpdater{}, ChannelCommunicator{})
+	e.Run(StopCriterion{Tol: 1e-4, MaxIter: 1, MinIter: 1, Patience: 1}, nil)
+
+	if rows, cols := e.W.Dims(); rows != m || cols != k {
+		t.Errorf("W dims = %dx%d, want %dx%d", rows, cols, m, k)
+	}
+	if rows, cols := e.H.Dims(); rows != k || cols != n {
+		t.Errorf("H dims = %dx%d, want %dx%d", rows, cols, k, n)
+	}
+}
+
+// TestNodeEnsembleReconfigureResumesRun exercises the malleable
+// reconfiguration path end to end: pause a Run in flight on one grid,
+// Reconfigure onto a different grid, then resume with another Run and
+// confirm it picks up the paused iterate and keeps improving.
+func TestNodeEnsembleReconfigureResumesRun(t *testing.T) {
+	origRows, origCols := numNodeRows, numNodeCols
+	defer func() {
+		numNodeRows, numNodeCols = origRows, origCols
+		recomputeBlockSizes()
+	}()
+
+	// HALSUpdater (unlike MultiplicativeUpdater) monotonically decreases
+	// reconstruction error on a random, non-low-rank A even at this package's
+	// full m/n/k scale, so an improvement assertion across Reconfigure is
+	// not at the mercy of the solver's own convergence behavior.
+	rand.Seed(8)
+	a := make([]float64, m*n)
+	for i := range a {
+		a[i] = rand.Float64()
+	}
+	A := mat.NewDense(m, n, a)
+
+	e := NewNodeEnsemble(A, 2, 2, HALSUpdater{}, ChannelCommunicator{})
+
+	// MaxIter is high enough that Run is still iterating, not stopping on
+	// its own, by the time Reconfigure pauses it.
+	done := make(chan struct{})
+	go func() {
+		e.Run(StopCriterion{Tol: 1e-4, MaxIter: 1000, MinIter: 1000, Patience: 1000}, nil)
+		close(done)
+	}()
+	time.Sleep(50 * time.Millisecond)
+
+	e.Reconfigure(4, 4)
+	<-done
+
+	if numNodeRows != 4 || numNodeCols != 4 {
+		t.Fatalf("grid shape after Reconfigure = %dx%d, want 4x4", numNodeRows, numNodeCols)
+	}
+	if rows, cols := e.W.Dims(); rows != m || cols != k {
+		t.Fatalf("paused W dims = %dx%d, want %dx%d", rows, cols, m, k)
+	}
+	if rows, cols := e.H.Dims(); rows != k || cols != n {
+		t.Fatalf("paused H dims = %dx%d, want %dx%d", rows, cols, k, n)
+	}
+	pausedErr := frobeniusError(A, e.W, e.H)
+
+	e.Run(StopCriterion{Tol: 1e-4, MaxIter: 5, MinIter: 5, Patience: 5}, nil)
+
+	if rows, cols := e.W.Dims(); rows != m || cols != k {
+		t.Errorf("resumed W dims = %dx%d, want %dx%d", rows, cols, m, k)
+	}
+	if rows, cols := e.H.Dims(); rows != k || cols != n {
+		t.Errorf("resumed H dims = %dx%d, want %dx%d", rows, cols, k, n)
+	}
+	resumedErr := frobeniusError(A, e.W, e.H)
+	if resumedErr >= pausedErr {
+		t.Errorf("reconstruction error did not improve after resuming on the new grid: paused=%v resumed=%v", pausedErr, resumedErr)
+	}
+}
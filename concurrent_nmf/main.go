@@ -21,24 +21,66 @@ func matPrint(X mat.Matrix) {
 // xi = ith row of X, x^i = ith column of X
 
 // Corresponding MPI-FAUN steps in comments
-func parallelNMF(node *Node, maxIter int) {
+// diagChan, if non-nil, receives the relative Frobenius residual computed
+// after every iteration (sent only by node 0, so callers get one value per
+// iteration rather than one per node) so callers can plot convergence.
+// pauseChan, if non-nil, makes parallelNMF stop after the current iteration
+// as soon as it is closed - the node still sends its latest Wij/Hji over
+// clientChan exactly as it does on normal completion, so a NodeEnsemble
+// reconfiguring the grid can collect the in-flight iterate the same way it
+// collects a finished one. initWij/initHji seed the iterate instead of a
+// random start (used to resume after a reconfiguration); either may be nil
+// to fall back to a random start for that matrix. wg is Done once this node
+// has sent its final Wij/Hji; the caller owns the WaitGroup (and its Add)
+// so each launcher - main's single run or a NodeEnsemble's per-grid Run -
+// tracks only the goroutines it actually started.
+func parallelNMF(node *Node, stop StopCriterion, updater NMFUpdater, reg Regularizer, diagChan chan<- float64, pauseChan <-chan struct{}, initWij, initHji *mat.Dense, wg *sync.WaitGroup) {
 	// Local matrices
 	var Wij, Hji mat.Dense
 
 	// 1) Initialize Hji - dims = k x (n/p)
-	h := make([]float64, k*smallBlockSizeH)
-	for i := range h {
-		h[i] = rand.NormFloat64()
+	if initHji != nil {
+		Hji = *initHji
+	} else {
+		h := make([]float64, k*smallBlockSizeH)
+		for i := range h {
+			h[i] = rand.NormFloat64()
+		}
+		Hji = *mat.NewDense(k, smallBlockSizeH, h)
 	}
-	Hji = *mat.NewDense(k, smallBlockSizeH, h)
 	// Not in paper, but initialize Wij too - dims = (m/p) x k
-	w := make([]float64, smallBlockSizeW*k)
-	for i := range w {
-		w[i] = rand.NormFloat64()
+	if initWij != nil {
+		Wij = *initWij
+	} else {
+		w := make([]float64, smallBlockSizeW*k)
+		for i := range w {
+			w[i] = rand.NormFloat64()
+		}
+		Wij = *mat.NewDense(smallBlockSizeW, k, w)
 	}
-	Wij = *mat.NewDense(smallBlockSizeW, k, w)
 
-	for iter := 0; iter < maxIter; iter++ {
+	// ||A||_F^2 never changes across iterations, so it is only worth one
+	// all-reduce up front rather than recomputing it every iteration.
+	localNormSq := mat.NewDense(1, 1, []float64{node.aPiece.FrobeniusNormSq()})
+	normSqA := node.allReduce(localNormSq).At(0, 0)
+	tracker := newConvergenceTracker(stop)
+
+iterLoop:
+	for iter := 0; iter < stop.MaxIter; iter++ {
+		// Nodes agree on pausing through an all-reduce, so they all stop at
+		// the same iteration and none is left waiting in a collective.
+		if pauseChan != nil {
+			paused := 0.0
+			select {
+			case <-pauseChan:
+				paused = 1
+			default:
+			}
+			if node.allReduce(mat.NewDense(1, 1, []float64{paused})).At(0, 0) > 0 {
+				break iterLoop
+			}
+		}
+
 		// Update W Part
 		// 3)
 		Uij := &mat.Dense{}
@@ -48,12 +90,13 @@ func parallelNMF(node *Node, maxIter int) {
 		// 5)
 		Hj := node.allGatherAcrossNodeColumns(&Hji) // k x (n/p_c)
 		// 6)
-		Vij := &mat.Dense{}
-		Vij.Mul(node.aPiece, Hj.T()) // (m/pr) x k
+		Vij := mat.DenseCopyOf(node.aPiece.MulDenseRight(Hj.T())) // (m/pr) x k
 		// 7)
 		HProductMatij := node.reduceScatterAcrossNodeRows(Vij) // (m/p) x k
 		// 8)
-		updateW(&Wij, HGramMat, HProductMatij)
+		if err := updater.UpdateW(&Wij, HGramMat, HProductMatij, reg); err != nil {
+			panic(err)
+		}
 		// Update H Part
 		// 9)
 		Xij := &mat.Dense{}
@@ -63,62 +106,51 @@ func parallelNMF(node *Node, maxIter int) {
 		// 11)
 		Wi := node.allGatherAcrossNodeRows(&Wij) // (m/p_r) x k
 		// 12)
-		Yij := &mat.Dense{}
-		Yij.Mul(Wi.T(), node.aPiece) // k x (n/p_c)
+		Yij := mat.DenseCopyOf(node.aPiece.MulDenseLeft(Wi.T())) // k x (n/p_c)
 		// 13)
 		WProductMatji := node.reduceScatterAcrossNodeColumns(Yij) // k x (n/p)
+
+		// tr(W^T A H^T) is only a partial sum over this node's column slice
+		// of H until it's summed across the whole grid.
+		localCross := mat.NewDense(1, 1, []float64{localCrossTerm(WProductMatji, &Hji)})
+		crossTerm := node.allReduce(localCross).At(0, 0)
+		residual := frobeniusResidual(normSqA, WGramMat, HGramMat, crossTerm)
+		if diagChan != nil && node.nodeID == 0 {
+			diagChan <- residual
+		}
+
 		// 14)
-		updateH(&Hji, WGramMat, WProductMatji)
+		if err := updater.UpdateH(&Hji, WGramMat, WProductMatji, reg); err != nil {
+			panic(err)
+		}
+
+		if tracker.observe(iter, residual) {
+			break iterLoop
+		}
 	}
 
 	// Send Wij & Hji to client
-	node.clientChan <- MatMessage{Wij, node.nodeID, true, false}
-	node.clientChan <- MatMessage{Hji, node.nodeID, false, true}
+	node.clientChan <- MatMessage{Wij, node.nodeID, true, false, 0}
+	node.clientChan <- MatMessage{Hji, node.nodeID, false, true, 0}
 
 	wg.Done()
 }
 
-// Line 8 of MPI-FAUN - Multiplicative Update: W = W * ((A @ Ht) / (W @ (H @ Ht)))
-// Formula uses: Gram matrix, matrix product w/ A, and W
-// 		W dims = (m/p) x k
-// 		HGramMat dims = k x k
-// 		HProductMatij dims = (m/p) x k
-func updateW(W *mat.Dense, HGramMat *mat.Dense, HProductMatij mat.Matrix) {
-	update := &mat.Dense{}
-	update.Mul(W, HGramMat) // (m/p) x k
-
-	update.DivElem(HProductMatij, update)
-	W.MulElem(W, update)
-}
-
-// Line 14 of MPI-FAUN - Multiplicative Update: H = H * ((Wt @ A) / ((Wt @ W) @ H))
-// Formula uses: Gram matrix, matrix product w/ A, and H
-// 		H dims = k x (n/p)
-// 		WGramMat dims = k x k
-// 		WProductMatji dims = k x (n/p)
-func updateH(H *mat.Dense, WGramMat *mat.Dense, WProductMatji mat.Matrix) {
-	update := &mat.Dense{}
-	update.Mul(WGramMat, H) // k x (n/p)
-
-	update.DivElem(WProductMatji, update)
-	H.MulElem(H, update)
-}
-
-func partitionAMatrix(A *mat.Dense) []mat.Matrix {
-	var piecesOfA []mat.Matrix
+func partitionAMatrix(A *mat.Dense) []LocalTile {
+	var piecesOfA []LocalTile
 
 	for i := 0; i < numNodeRows; i++ {
 		for j := 0; j < numNodeCols; j++ {
 			aPiece := A.Slice(largeBlockSizeW*i, largeBlockSizeW*(i+1), largeBlockSizeH*j, largeBlockSizeH*(j+1))
 			// Make pieces each their own copies of the data
-			piecesOfA = append(piecesOfA, mat.DenseCopyOf(aPiece))
+			piecesOfA = append(piecesOfA, DenseTile{mat.DenseCopyOf(aPiece)})
 		}
 	}
 
 	return piecesOfA
 }
 
-func makeNode(chans [numNodes]chan MatMessage, akChans [numNodes]chan bool, clientChan chan MatMessage, id int, aPiece mat.Matrix) *Node {
+func makeNode(chans []chan MatMessage, akChans []chan bool, clientChan chan MatMessage, id int, aPiece LocalTile, comm Communicator) *Node {
 	return &Node{
 		nodeID:     id,
 		nodeChans:  chans,
@@ -127,19 +159,20 @@ func makeNode(chans [numNodes]chan MatMessage, akChans [numNodes]chan bool, clie
 		aPiece:     aPiece,
 		aks:        akChans[id],
 		clientChan: clientChan,
+		comm:       comm,
 	}
 }
 
-func makeMatrixChans() [numNodes]chan MatMessage {
-	var chans [numNodes]chan MatMessage
+func makeMatrixChans() []chan MatMessage {
+	chans := make([]chan MatMessage, numNodes)
 	for ch := range chans {
 		chans[ch] = make(chan MatMessage, numNodes*3)
 	}
 	return chans
 }
 
-func makeAkChans() [numNodes]chan bool {
-	var chans [numNodes]chan bool
+func makeAkChans() []chan bool {
+	chans := make([]chan bool, numNodes)
 	for ch := range chans {
 		chans[ch] = make(chan bool, numNodes*3)
 	}
@@ -157,15 +190,30 @@ var wg sync.WaitGroup
 //const numNodes, numNodeRows, numNodeCols = 512, 32, 16
 
 const m, n, k = 2048, 1024, 400
-const numNodes, numNodeRows, numNodeCols = 128, 16, 8
 
-const largeBlockSizeW = m / numNodeRows
-const largeBlockSizeH = n / numNodeCols
-const smallBlockSizeW = m / numNodes
-const smallBlockSizeH = n / numNodes
+// numNodes, numNodeRows, numNodeCols and the block sizes derived from them
+// are vars, not consts, so that NodeEnsemble.Reconfigure can grow or shrink
+// the process grid between iterations; recomputeBlockSizes keeps the block
+// sizes in sync whenever the grid shape changes.
+var numNodeRows, numNodeCols = 16, 8
+var numNodes = numNodeRows * numNodeCols
+
+var largeBlockSizeW = m / numNodeRows
+var largeBlockSizeH = n / numNodeCols
+var smallBlockSizeW = m / numNodes
+var smallBlockSizeH = n / numNodes
+
+// recomputeBlockSizes must be called after numNodeRows/numNodeCols change.
+func recomputeBlockSizes() {
+	numNodes = numNodeRows * numNodeCols
+	largeBlockSizeW = m / numNodeRows
+	largeBlockSizeH = n / numNodeCols
+	smallBlockSizeW = m / numNodes
+	smallBlockSizeH = n / numNodes
+}
 
 func main() {
-	maxIter := 100
+	stop := DefaultStopCriterion()
 
 	// Initialize input matrix A
 	a := make([]float64, m*n)
@@ -186,18 +234,32 @@ func main() {
 	chans := makeMatrixChans()
 	akChans := makeAkChans()
 	clientChan := make(chan MatMessage, numNodes*3)
-	var nodes [numNodes]*Node
+	// The in-process Go-channel transport; swap in an MPICommunicator to
+	// run the same algorithm across a real cluster.
+	comm := ChannelCommunicator{}
+	nodes := make([]*Node, numNodes)
 	for i := 0; i < numNodes; i++ {
 		id := i
-		nodes[i] = makeNode(chans, akChans, clientChan, id, piecesOfA[i])
+		nodes[i] = makeNode(chans, akChans, clientChan, id, piecesOfA[i], comm)
 	}
 
 	startTime := time.Now()
 
-	// Launch nodes with their A pieces
+	// Launch nodes with their A pieces. MPI-FAUN is a framework over a
+	// swappable local NLS solver - pick the multiplicative update here, or
+	// swap in HALSUpdater{} / ANLSBPPUpdater{}.
+	var updater NMFUpdater = MultiplicativeUpdater{}
+	diagChan := make(chan float64, stop.MaxIter)
+	go func() {
+		for residual := range diagChan {
+			//fmt.Println("residual:", residual)
+			_ = residual
+		}
+	}()
+	var reg Regularizer // zero value: unregularized, matching the original behavior
 	for _, node := range nodes {
 		wg.Add(1)
-		go parallelNMF(node, maxIter)
+		go parallelNMF(node, stop, updater, reg, diagChan, nil, nil, nil, &wg)
 	}
 
 	// Wait for W & H blocks from nodes
@@ -213,6 +275,7 @@ func main() {
 		}
 	}
 	wg.Wait()
+	close(diagChan)
 
 	// Construct W
 	w := make([]float64, m*k)
@@ -0,0 +1,174 @@
+//go:build mpi
+
+package main
+
+/*
+#cgo pkg-config: ompi-c
+#include <mpi.h>
+#include <stdlib.h>
+*/
+import "C"
+
+import (
+	"sync"
+	"unsafe"
+
+	"gonum.org/v1/gonum/mat"
+)
+
+// MPICommunicator dispatches the same collectives ChannelCommunicator
+// implements with goroutines onto a real MPI cluster, using cgo bindings
+// directly against libmpi (an actual MPI installation, e.g. Open MPI, is
+// required at build and link time). It is built only with -tags mpi.
+//
+// Node.nodeID is taken to be the MPI world rank. Row and column
+// sub-communicators are built lazily via MPI_Comm_split and cached by grid
+// position so every collective call reuses them instead of re-splitting.
+type MPICommunicator struct {
+	world C.MPI_Comm
+
+	mu       sync.Mutex
+	rowComms map[int]C.MPI_Comm
+	colComms map[int]C.MPI_Comm
+}
+
+// NewMPICommunicator initializes MPI (if not already) and returns a
+// communicator ready to use as a Node's comm field.
+func NewMPICommunicator() *MPICommunicator {
+	var initialized C.int
+	C.MPI_Initialized(&initialized)
+	if initialized == 0 {
+		C.MPI_Init(nil, nil)
+	}
+	return &MPICommunicator{
+		world:    C.MPI_COMM_WORLD,
+		rowComms: make(map[int]C.MPI_Comm),
+		colComms: make(map[int]C.MPI_Comm),
+	}
+}
+
+// rowComm returns (splitting and caching on first use) the communicator
+// grouping every rank in node's node-row, ranked by ascending column index.
+func (c *MPICommunicator) rowComm(node *Node) C.MPI_Comm {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	if comm, ok := c.rowComms[node.rowIdx()]; ok {
+		return comm
+	}
+	var comm C.MPI_Comm
+	C.MPI_Comm_split(c.world, C.int(node.rowIdx()), C.int(node.colIdx()), &comm)
+	c.rowComms[node.rowIdx()] = comm
+	return comm
+}
+
+// colComm returns (splitting and caching on first use) the communicator
+// grouping every rank in node's node-column, ranked by ascending row index.
+func (c *MPICommunicator) colComm(node *Node) C.MPI_Comm {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	if comm, ok := c.colComms[node.colIdx()]; ok {
+		return comm
+	}
+	var comm C.MPI_Comm
+	C.MPI_Comm_split(c.world, C.int(node.colIdx()), C.int(node.rowIdx()), &comm)
+	c.colComms[node.colIdx()] = comm
+	return comm
+}
+
+// flatten copies a *mat.Dense into a row-major []float64 buffer for
+// MPI_DOUBLE transport.
+func flatten(m *mat.Dense) []float64 {
+	rows, cols := m.Dims()
+	buf := make([]float64, rows*cols)
+	for i := 0; i < rows; i++ {
+		for j := 0; j < cols; j++ {
+			buf[i*cols+j] = m.At(i, j)
+		}
+	}
+	return buf
+}
+
+func reshape(buf []float64, rows, cols int) *mat.Dense {
+	return mat.NewDense(rows, cols, buf)
+}
+
+func doublePtr(buf []float64) unsafe.Pointer {
+	return unsafe.Pointer(&buf[0])
+}
+
+// AllReduce sums local across the whole world communicator.
+func (c *MPICommunicator) AllReduce(node *Node, local *mat.Dense) *mat.Dense {
+	rows, cols := local.Dims()
+	send := flatten(local)
+	recv := make([]float64, len(send))
+	C.MPI_Allreduce(doublePtr(send), doublePtr(recv), C.int(len(send)), C.MPI_DOUBLE, C.MPI_SUM, c.world)
+	return reshape(recv, rows, cols)
+}
+
+// AllGatherAcrossNodeRows gathers a node-row's pieces, ordered by rank
+// within the row communicator (i.e. by column index), into one matrix.
+func (c *MPICommunicator) AllGatherAcrossNodeRows(node *Node, local *mat.Dense) *mat.Dense {
+	rows, cols := local.Dims()
+	send := flatten(local)
+	recv := make([]float64, len(send)*numNodeCols)
+	C.MPI_Allgather(doublePtr(send), C.int(len(send)), C.MPI_DOUBLE, doublePtr(recv), C.int(len(send)), C.MPI_DOUBLE, c.rowComm(node))
+	return reshape(recv, rows*numNodeCols, cols)
+}
+
+// AllGatherAcrossNodeColumns gathers a node-column's pieces, ordered by rank
+// within the column communicator (i.e. by row index), into one matrix.
+// Since MPI_Allgather concatenates by rank in row-major buffer order, the
+// pieces are transposed before and after transport so the column-wise
+// concatenation lands in the right place.
+func (c *MPICommunicator) AllGatherAcrossNodeColumns(node *Node, local *mat.Dense) *mat.Dense {
+	rows, cols := local.Dims()
+	localT := &mat.Dense{}
+	localT.CloneFrom(local.T())
+	send := flatten(localT)
+	recv := make([]float64, len(send)*numNodeRows)
+	C.MPI_Allgather(doublePtr(send), C.int(len(send)), C.MPI_DOUBLE, doublePtr(recv), C.int(len(send)), C.MPI_DOUBLE, c.colComm(node))
+	gatheredT := reshape(recv, cols*numNodeRows, rows)
+
+	out := &mat.Dense{}
+	out.CloneFrom(gatheredT.T())
+	return out
+}
+
+// ReduceScatterAcrossNodeRows sums the row communicator's contributions and
+// scatters the row-block matching this rank's position back to it.
+func (c *MPICommunicator) ReduceScatterAcrossNodeRows(node *Node, local *mat.Dense) *mat.Dense {
+	rows, cols := local.Dims()
+	send := flatten(local)
+	chunk := (rows / numNodeCols) * cols
+	counts := make([]C.int, numNodeCols)
+	for i := range counts {
+		counts[i] = C.int(chunk)
+	}
+	recv := make([]float64, chunk)
+	C.MPI_Reduce_scatter(doublePtr(send), doublePtr(recv), &counts[0], C.MPI_DOUBLE, C.MPI_SUM, c.rowComm(node))
+	return reshape(recv, rows/numNodeCols, cols)
+}
+
+// ReduceScatterAcrossNodeColumns sums the column communicator's
+// contributions and scatters the column-block matching this rank's
+// position back to it.
+func (c *MPICommunicator) ReduceScatterAcrossNodeColumns(node *Node, local *mat.Dense) *mat.Dense {
+	rows, cols := local.Dims()
+	// Scatter along columns, so transpose to scatter contiguous rows and
+	// transpose back, same trick as AllGatherAcrossNodeColumns.
+	localT := &mat.Dense{}
+	localT.CloneFrom(local.T())
+	send := flatten(localT)
+	chunk := (cols / numNodeRows) * rows
+	counts := make([]C.int, numNodeRows)
+	for i := range counts {
+		counts[i] = C.int(chunk)
+	}
+	recv := make([]float64, chunk)
+	C.MPI_Reduce_scatter(doublePtr(send), doublePtr(recv), &counts[0], C.MPI_DOUBLE, C.MPI_SUM, c.colComm(node))
+
+	scatteredT := reshape(recv, cols/numNodeRows, rows)
+	out := &mat.Dense{}
+	out.CloneFrom(scatteredT.T())
+	return out
+}
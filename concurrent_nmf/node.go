@@ -0,0 +1,82 @@
+package main
+
+import "gonum.org/v1/gonum/mat"
+
+// MatMessage is what flows over the node-to-node and node-to-client
+// channels: a matrix tagged with which node produced it and which
+// collective call it belongs to, plus markers for the two kinds of final
+// result the client is waiting on.
+type MatMessage struct {
+	mtx      mat.Dense
+	sentID   int
+	isFinalW bool
+	isFinalH bool
+	seq      int
+}
+
+// Node is one cell of the p_r x p_c process grid, nodeID = rowIdx*numNodeCols+colIdx.
+// Its collective operations are delegated to a Communicator so the same
+// parallelNMF code runs unchanged whether nodes are goroutines on one
+// machine or ranks on an MPI cluster.
+type Node struct {
+	nodeID     int
+	nodeChans  []chan MatMessage
+	nodeAks    []chan bool
+	inChan     chan MatMessage
+	aks        chan bool
+	aPiece     LocalTile
+	clientChan chan MatMessage
+	comm       Communicator
+
+	seq     int
+	pending map[int][]MatMessage
+}
+
+func (n *Node) rowIdx() int { return n.nodeID / numNodeCols }
+func (n *Node) colIdx() int { return n.nodeID % numNodeCols }
+
+// receive pulls the message tagged with seq off this node's inbox, stashing
+// any messages for other (not-yet-awaited) collective calls in a pending
+// buffer so calls that race ahead of their peers don't corrupt each other.
+func (n *Node) receive(seq int) MatMessage {
+	if bucket, ok := n.pending[seq]; ok && len(bucket) > 0 {
+		msg := bucket[0]
+		n.pending[seq] = bucket[1:]
+		return msg
+	}
+	for {
+		msg := <-n.inChan
+		if msg.seq == seq {
+			return msg
+		}
+		if n.pending == nil {
+			n.pending = make(map[int][]MatMessage)
+		}
+		n.pending[msg.seq] = append(n.pending[msg.seq], msg)
+	}
+}
+
+func (n *Node) nextSeq() int {
+	n.seq++
+	return n.seq
+}
+
+func (n *Node) allReduce(local *mat.Dense) *mat.Dense {
+	return n.comm.AllReduce(n, local)
+}
+
+func (n *Node) allGatherAcrossNodeRows(local *mat.Dense) *mat.Dense {
+	return n.comm.AllGatherAcrossNodeRows(n, local)
+}
+
+func (n *Node) allGatherAcrossNodeColumns(local *mat.Dense) *mat.Dense {
+	return n.comm.AllGatherAcrossNodeColumns(n, local)
+}
+
+func (n *Node) reduceScatterAcrossNodeRows(local *mat.Dense) *mat.Dense {
+	return n.comm.ReduceScatterAcrossNodeRows(n, local)
+}
+
+func (n *Node) reduceScatterAcrossNodeColumns(local *mat.Dense) *mat.Dense {
+	return n.comm.ReduceScatterAcrossNodeColumns(n, local)
+}
@@ -0,0 +1,64 @@
+package main
+
+import "gonum.org/v1/gonum/mat"
+
+// Regularizer configures the penalty terms added to an NMFUpdater's
+// multiplicative-update denominator: L2 (Frobenius) shrinkage, L1
+// sparsity, and an orthogonality constraint on H. All three quantities an
+// updater needs to evaluate a Regularizer (W or H, and the matching Gram
+// matrix) are already local after the existing all-reduce/all-gather
+// steps, so applying one costs no additional communication.
+type Regularizer struct {
+	L2 float64 // Frobenius penalty weight: denominator += L2 * X
+	L1 float64 // sparsity penalty weight: denominator += L1
+	// Ortho is the orthogonality penalty weight encouraging H's rows
+	// toward orthogonality. Because the formula's natural home is the
+	// W-update's denominator (it needs W and HGramMat = H Hᵀ, which is
+	// what WPenalty receives), it only takes effect there.
+	Ortho float64
+}
+
+// penalty returns the shared L1/L2 contribution to the denominator for
+// either update, given the matrix being updated (W or H).
+func (r Regularizer) penalty(X *mat.Dense) *mat.Dense {
+	rows, cols := X.Dims()
+	penalty := mat.NewDense(rows, cols, nil)
+	if r.L2 != 0 {
+		term := &mat.Dense{}
+		term.Scale(r.L2, X)
+		penalty.Add(penalty, term)
+	}
+	if r.L1 != 0 {
+		for i := 0; i < rows; i++ {
+			for j := 0; j < cols; j++ {
+				penalty.Set(i, j, penalty.At(i, j)+r.L1)
+			}
+		}
+	}
+	return penalty
+}
+
+// WPenalty returns the term added to the W-update's denominator (W @
+// HGramMat): L2 + L1, plus - if Ortho is set - λ·W·(H Hᵀ - I).
+func (r Regularizer) WPenalty(W *mat.Dense, HGramMat mat.Matrix) *mat.Dense {
+	penalty := r.penalty(W)
+	if r.Ortho != 0 {
+		k, _ := HGramMat.Dims()
+		shifted := mat.DenseCopyOf(HGramMat)
+		for i := 0; i < k; i++ {
+			shifted.Set(i, i, shifted.At(i, i)-1)
+		}
+		ortho := &mat.Dense{}
+		ortho.Mul(W, shifted)
+		ortho.Scale(r.Ortho, ortho)
+		penalty.Add(penalty, ortho)
+	}
+	return penalty
+}
+
+// HPenalty returns the term added to the H-update's denominator (WGramMat
+// @ H): L2 + L1. WGramMat is accepted for symmetry with WPenalty, even
+// though the shared L1/L2 penalty doesn't need it.
+func (r Regularizer) HPenalty(H *mat.Dense, WGramMat mat.Matrix) *mat.Dense {
+	return r.penalty(H)
+}
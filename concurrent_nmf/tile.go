@@ -0,0 +1,155 @@
+package main
+
+import (
+	"math"
+	"sort"
+
+	"gonum.org/v1/gonum/mat"
+)
+
+// LocalTile is the local A_ij tile held by a node. parallelNMF only ever
+// multiplies its tile against a dense matrix gathered from its row/column
+// (steps 6 and 12), so that is the entire surface the algorithm needs -
+// dense and sparse tiles can implement it without anything else in
+// parallelNMF knowing which one it has.
+type LocalTile interface {
+	// MulDenseRight returns tile * H, e.g. A_ij * Hj^T ((m/pr) x k).
+	MulDenseRight(H mat.Matrix) mat.Matrix
+	// MulDenseLeft returns W * tile, e.g. Wi^T * A_ij (k x (n/pc)).
+	MulDenseLeft(W mat.Matrix) mat.Matrix
+	// FrobeniusNormSq returns ||tile||_F^2, the local contribution to
+	// ||A||_F^2 used by the convergence check.
+	FrobeniusNormSq() float64
+}
+
+// DenseTile is a LocalTile backed by a dense *mat.Dense piece of A.
+type DenseTile struct {
+	*mat.Dense
+}
+
+func (t DenseTile) MulDenseRight(H mat.Matrix) mat.Matrix {
+	result := &mat.Dense{}
+	result.Mul(t.Dense, H)
+	return result
+}
+
+func (t DenseTile) MulDenseLeft(W mat.Matrix) mat.Matrix {
+	result := &mat.Dense{}
+	result.Mul(W, t.Dense)
+	return result
+}
+
+func (t DenseTile) FrobeniusNormSq() float64 {
+	return math.Pow(mat.Norm(t.Dense, 2), 2)
+}
+
+// Triplet is one nonzero of A in coordinate form, row/col indexed within the
+// full (unpartitioned) matrix.
+type Triplet struct {
+	Row, Col int
+	Value    float64
+}
+
+// CSRTile is a LocalTile backed by a sparse piece of A stored in compressed
+// sparse row form, so that a node's share of A is never materialized as a
+// dense (m/p_r) x (n/p_c) block of floats.
+type CSRTile struct {
+	rows, cols int
+	rowPtr     []int // len = rows+1
+	colIdx     []int // len = nnz, sorted within each row
+	vals       []float64
+}
+
+// NewCSRTile builds a CSRTile of the given local dimensions from triplets
+// already translated into tile-local (row, col) coordinates.
+func NewCSRTile(rows, cols int, triplets []Triplet) *CSRTile {
+	sorted := make([]Triplet, len(triplets))
+	copy(sorted, triplets)
+	sort.Slice(sorted, func(i, j int) bool {
+		if sorted[i].Row != sorted[j].Row {
+			return sorted[i].Row < sorted[j].Row
+		}
+		return sorted[i].Col < sorted[j].Col
+	})
+
+	t := &CSRTile{
+		rows:   rows,
+		cols:   cols,
+		rowPtr: make([]int, rows+1),
+		colIdx: make([]int, len(sorted)),
+		vals:   make([]float64, len(sorted)),
+	}
+	for i, trip := range sorted {
+		t.colIdx[i] = trip.Col
+		t.vals[i] = trip.Value
+		t.rowPtr[trip.Row+1]++
+	}
+	for r := 0; r < rows; r++ {
+		t.rowPtr[r+1] += t.rowPtr[r]
+	}
+	return t
+}
+
+// MulDenseRight returns tile * H without ever forming a dense copy of tile.
+func (t *CSRTile) MulDenseRight(H mat.Matrix) mat.Matrix {
+	_, hCols := H.Dims()
+	result := mat.NewDense(t.rows, hCols, nil)
+	for r := 0; r < t.rows; r++ {
+		for idx := t.rowPtr[r]; idx < t.rowPtr[r+1]; idx++ {
+			c, v := t.colIdx[idx], t.vals[idx]
+			for j := 0; j < hCols; j++ {
+				result.Set(r, j, result.At(r, j)+v*H.At(c, j))
+			}
+		}
+	}
+	return result
+}
+
+// FrobeniusNormSq returns ||tile||_F^2 by summing the squares of the
+// nonzero values - the implicit zeros contribute nothing.
+func (t *CSRTile) FrobeniusNormSq() float64 {
+	sum := 0.0
+	for _, v := range t.vals {
+		sum += v * v
+	}
+	return sum
+}
+
+// MulDenseLeft returns W * tile without ever forming a dense copy of tile.
+func (t *CSRTile) MulDenseLeft(W mat.Matrix) mat.Matrix {
+	wRows, _ := W.Dims()
+	result := mat.NewDense(wRows, t.cols, nil)
+	for r := 0; r < t.rows; r++ {
+		for idx := t.rowPtr[r]; idx < t.rowPtr[r+1]; idx++ {
+			c, v := t.colIdx[idx], t.vals[idx]
+			for i := 0; i < wRows; i++ {
+				result.Set(i, c, result.At(i, c)+W.At(i, r)*v)
+			}
+		}
+	}
+	return result
+}
+
+// partitionSparseAMatrix bins the triplets of A into numNodeRows x
+// numNodeCols CSR tiles, each sized largeBlockSizeW x largeBlockSizeH, the
+// sparse counterpart to partitionAMatrix.
+func partitionSparseAMatrix(triplets []Triplet, numNodeRows, numNodeCols, largeBlockSizeW, largeBlockSizeH int) []LocalTile {
+	buckets := make([][]Triplet, numNodeRows*numNodeCols)
+	for _, trip := range triplets {
+		tileRow := trip.Row / largeBlockSizeW
+		tileCol := trip.Col / largeBlockSizeH
+		bucket := tileRow*numNodeCols + tileCol
+		localTrip := Triplet{
+			Row:   trip.Row - tileRow*largeBlockSizeW,
+			Col:   trip.Col - tileCol*largeBlockSizeH,
+			Value: trip.Value,
+		}
+		buckets[bucket] = append(buckets[bucket], localTrip)
+	}
+
+	tiles := make([]LocalTile, len(buckets))
+	for i, bucket := range buckets {
+		tiles[i] = NewCSRTile(largeBlockSizeW, largeBlockSizeH, bucket)
+	}
+	return tiles
+}
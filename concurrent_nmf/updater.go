@@ -0,0 +1,317 @@
+package main
+
+import (
+	"errors"
+	"math"
+
+	"gonum.org/v1/gonum/mat"
+)
+
+// NMFUpdater is the pluggable local-solver interface MPI-FAUN is built around:
+// parallelNMF only needs the Gram matrix and the locally-assembled RHS to take
+// a step on its tile of W (or H), so any NLS solver that consumes those two
+// matrices can be swapped in without touching the communication pattern.
+//
+//	Wij dims = (m/p) x k, HGramMat dims = k x k, HProductMatij dims = (m/p) x k
+//	Hji dims = k x (n/p), WGramMat dims = k x k, WProductMatji dims = k x (n/p)
+//
+// reg configures optional L1/L2/orthogonality penalties (see Regularizer);
+// the zero value applies none of them, matching the original unregularized
+// behavior.
+type NMFUpdater interface {
+	UpdateW(Wij *mat.Dense, HGramMat, HProductMatij mat.Matrix, reg Regularizer) error
+	UpdateH(Hji *mat.Dense, WGramMat, WProductMatji mat.Matrix, reg Regularizer) error
+}
+
+// MultiplicativeUpdater is the original Lee-Seung multiplicative update rule:
+// W = W * ((A @ Ht) / (W @ (H @ Ht))).
+type MultiplicativeUpdater struct{}
+
+// Line 8 of MPI-FAUN - Multiplicative Update:
+// W = W * (HProductMatij / (W @ HGramMat + reg.WPenalty(W, HGramMat)))
+func (MultiplicativeUpdater) UpdateW(W *mat.Dense, HGramMat, HProductMatij mat.Matrix, reg Regularizer) error {
+	denom := &mat.Dense{}
+	denom.Mul(W, HGramMat) // (m/p) x k
+	denom.Add(denom, reg.WPenalty(W, HGramMat))
+
+	update := &mat.Dense{}
+	update.DivElem(HProductMatij, denom)
+	W.MulElem(W, update)
+	return nil
+}
+
+// Line 14 of MPI-FAUN - Multiplicative Update:
+// H = H * (WProductMatji / (WGramMat @ H + reg.HPenalty(H, WGramMat)))
+func (MultiplicativeUpdater) UpdateH(H *mat.Dense, WGramMat, WProductMatji mat.Matrix, reg Regularizer) error {
+	denom := &mat.Dense{}
+	denom.Mul(WGramMat, H) // k x (n/p)
+	denom.Add(denom, reg.HPenalty(H, WGramMat))
+
+	update := &mat.Dense{}
+	update.DivElem(WProductMatji, denom)
+	H.MulElem(H, update)
+	return nil
+}
+
+// HALSUpdater is Hierarchical Alternating Least Squares: each column of W (row
+// of H) is solved in closed form by coordinate descent, holding every other
+// column fixed. Both HGramMat (= H @ Ht) and HProductMatij (= A @ Ht) are
+// already local, so a HALS step costs no additional communication.
+type HALSUpdater struct{}
+
+// UpdateW solves w_j <- max(0, w_j + (HProductMatij_j - W @ HGramMat_j) / HGramMat[j][j])
+// one column j at a time, using the freshly updated columns for the rest of
+// j's pass. reg.L2 is added to the per-column denominator and reg.L1 is
+// subtracted from the step's numerator; reg.Ortho is not supported here (its
+// formula only fits the multiplicative denominator's matrix form).
+func (HALSUpdater) UpdateW(W *mat.Dense, HGramMat, HProductMatij mat.Matrix, reg Regularizer) error {
+	rows, k := W.Dims()
+	gk, _ := HGramMat.Dims()
+	if gk != k {
+		return errors.New("hals: HGramMat dimension does not match W")
+	}
+
+	WH := &mat.Dense{}
+	WH.Mul(W, HGramMat) // (m/p) x k, recomputed as columns are updated below
+
+	for j := 0; j < k; j++ {
+		denom := HGramMat.At(j, j) + reg.L2
+		if denom <= 0 {
+			continue
+		}
+		for i := 0; i < rows; i++ {
+			v := W.At(i, j) + (HProductMatij.At(i, j)-WH.At(i, j)-reg.L1)/denom
+			if v < 0 {
+				v = 0
+			}
+			delta := v - W.At(i, j)
+			if delta != 0 {
+				W.Set(i, j, v)
+				// Keep W @ HGramMat consistent with the column we just moved.
+				for l := 0; l < k; l++ {
+					WH.Set(i, l, WH.At(i, l)+delta*HGramMat.At(j, l))
+				}
+			}
+		}
+	}
+	return nil
+}
+
+// UpdateH mirrors UpdateW, solving one row of H at a time.
+func (HALSUpdater) UpdateH(H *mat.Dense, WGramMat, WProductMatji mat.Matrix, reg Regularizer) error {
+	k, cols := H.Dims()
+	gk, _ := WGramMat.Dims()
+	if gk != k {
+		return errors.New("hals: WGramMat dimension does not match H")
+	}
+
+	WH := &mat.Dense{}
+	WH.Mul(WGramMat, H) // k x (n/p)
+
+	for j := 0; j < k; j++ {
+		denom := WGramMat.At(j, j) + reg.L2
+		if denom <= 0 {
+			continue
+		}
+		for i := 0; i < cols; i++ {
+			v := H.At(j, i) + (WProductMatji.At(j, i)-WH.At(j, i)-reg.L1)/denom
+			if v < 0 {
+				v = 0
+			}
+			delta := v - H.At(j, i)
+			if delta != 0 {
+				H.Set(j, i, v)
+				for l := 0; l < k; l++ {
+					WH.Set(l, i, WH.At(l, i)+delta*WGramMat.At(l, j))
+				}
+			}
+		}
+	}
+	return nil
+}
+
+// ANLSBPPUpdater solves the block-principal-pivoting non-negative least
+// squares problem min ||H^T W^T - A^T||_F^2 s.t. W >= 0, one row of W (column
+// of H) at a time, by tracking active (held at zero) and passive (free)
+// variable sets and swapping infeasible variables until the KKT conditions
+// are satisfied. As with HALS, the Gram matrix and product matrix already
+// local to each node are the only inputs the solver needs.
+type ANLSBPPUpdater struct {
+	// MaxSwaps bounds the number of active/passive re-partitions per row;
+	// once it is reached the last solve is kept, with active and negative
+	// entries set to 0. 0 uses a sane default.
+	MaxSwaps int
+}
+
+func (u ANLSBPPUpdater) maxSwaps() int {
+	if u.MaxSwaps > 0 {
+		return u.MaxSwaps
+	}
+	return 20
+}
+
+// UpdateW solves each row of W against the normal equations (HGramMat +
+// reg.L2*I) @ w_i^T = HProductMatij_i^T - reg.L1 via block principal
+// pivoting - the standard ridge-on-the-Gram / shift-the-RHS form of L2/L1
+// regularized NNLS. reg.Ortho is not supported here, for the same reason
+// it isn't in HALSUpdater.
+func (u ANLSBPPUpdater) UpdateW(W *mat.Dense, HGramMat, HProductMatij mat.Matrix, reg Regularizer) error {
+	rows, k := W.Dims()
+	gram := regularizedGram(HGramMat, reg.L2)
+	for i := 0; i < rows; i++ {
+		rhs := make([]float64, k)
+		for j := 0; j < k; j++ {
+			rhs[j] = HProductMatij.At(i, j) - reg.L1
+		}
+		x, err := bppSolveRow(gram, rhs, u.maxSwaps())
+		if err != nil {
+			return err
+		}
+		for j := 0; j < k; j++ {
+			W.Set(i, j, x[j])
+		}
+	}
+	return nil
+}
+
+// UpdateH solves each column of H against (WGramMat + reg.L2*I) @ h^j =
+// WProductMatji^j - reg.L1.
+func (u ANLSBPPUpdater) UpdateH(H *mat.Dense, WGramMat, WProductMatji mat.Matrix, reg Regularizer) error {
+	k, cols := H.Dims()
+	gram := regularizedGram(WGramMat, reg.L2)
+	for i := 0; i < cols; i++ {
+		rhs := make([]float64, k)
+		for j := 0; j < k; j++ {
+			rhs[j] = WProductMatji.At(j, i) - reg.L1
+		}
+		x, err := bppSolveRow(gram, rhs, u.maxSwaps())
+		if err != nil {
+			return err
+		}
+		for j := 0; j < k; j++ {
+			H.Set(j, i, x[j])
+		}
+	}
+	return nil
+}
+
+// regularizedGram returns gram + l2*I, or gram unchanged if l2 is 0.
+func regularizedGram(gram mat.Matrix, l2 float64) mat.Matrix {
+	if l2 == 0 {
+		return gram
+	}
+	k, _ := gram.Dims()
+	out := mat.DenseCopyOf(gram)
+	for i := 0; i < k; i++ {
+		out.Set(i, i, out.At(i, i)+l2)
+	}
+	return out
+}
+
+// bppSolveRow runs the block principal pivoting algorithm (Kim & Park) for a
+// single right-hand side: find x >= 0 minimizing 0.5*x^T*gram*x - rhs^T*x
+// (the normal-equations form of the NNLS problem) by
+// maintaining passive set P (unconstrained) and active set F = complement(P),
+// solving the unconstrained least squares on P, and swapping any variable
+// that violates feasibility (x_P < 0 or the KKT dual y_F < 0) until none do.
+func bppSolveRow(gram mat.Matrix, rhs []float64, maxSwaps int) ([]float64, error) {
+	k := len(rhs)
+	passive := make([]bool, k) // true => variable is free (in P)
+	for j := range passive {
+		passive[j] = true
+	}
+
+	x := make([]float64, k)
+	for swap := 0; swap <= maxSwaps; swap++ {
+		if err := solvePassiveBlock(gram, rhs, passive, x); err != nil {
+			return nil, err
+		}
+
+		// y = gram*x - rhs is the gradient of that objective; KKT requires x_P >= 0
+		// and y_F >= 0.
+		y := make([]float64, k)
+		for i := 0; i < k; i++ {
+			sum := 0.0
+			for j := 0; j < k; j++ {
+				sum += gram.At(i, j) * x[j]
+			}
+			y[i] = sum - rhs[i]
+		}
+
+		violated := false
+		for j := 0; j < k; j++ {
+			if passive[j] && x[j] < -1e-10 {
+				passive[j] = false
+				violated = true
+			} else if !passive[j] && y[j] < -1e-10 {
+				passive[j] = true
+				violated = true
+			}
+		}
+		if !violated {
+			break
+		}
+	}
+
+	for j := 0; j < k; j++ {
+		if !passive[j] || x[j] < 0 {
+			x[j] = 0
+		}
+	}
+	return x, nil
+}
+
+// degenerateDiagTol is how close a passive variable's Gram diagonal entry
+// can get to 0 before it's treated as rank-deficient (e.g. a W or H
+// component that has collapsed to all-zero, a routine occurrence during
+// NMF convergence) rather than solved for.
+const degenerateDiagTol = 1e-10
+
+// solvePassiveBlock solves the unconstrained least squares gram_PP * x_P = rhs_P,
+// fixing every active variable at zero, via Gaussian elimination. Passive
+// variables whose Gram diagonal entry is ~0 would make gram_PP singular
+// (mirroring HALSUpdater's `denom <= 0` guard) and are held at 0 instead of
+// included in the solve; if gram_PP is singular even after that filtering
+// (e.g. two passive dimensions that are linearly dependent rather than
+// individually degenerate), the whole block is held at 0 for this row
+// rather than propagating a solver error up through parallelNMF.
+func solvePassiveBlock(gram mat.Matrix, rhs []float64, passive []bool, x []float64) error {
+	var idx []int
+	for j, p := range passive {
+		if p && gram.At(j, j) > degenerateDiagTol {
+			idx = append(idx, j)
+		}
+	}
+	for j := range x {
+		x[j] = 0
+	}
+	if len(idx) == 0 {
+		return nil
+	}
+
+	n := len(idx)
+	A := mat.NewDense(n, n, nil)
+	b := mat.NewVecDense(n, nil)
+	for r, gr := range idx {
+		for c, gc := range idx {
+			A.Set(r, c, gram.At(gr, gc))
+		}
+		b.SetVec(r, rhs[gr])
+	}
+
+	var sol mat.VecDense
+	if err := sol.SolveVec(A, b); err != nil {
+		// Still singular despite filtering out individually-degenerate
+		// dimensions - hold this row's passive block at 0 rather than
+		// crashing the run over an ordinary rank-deficient Gram block.
+		return nil
+	}
+	for r, gr := range idx {
+		v := sol.AtVec(r)
+		if math.IsNaN(v) || math.IsInf(v, 0) {
+			v = 0
+		}
+		x[gr] = v
+	}
+	return nil
+}
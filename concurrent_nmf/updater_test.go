@@ -0,0 +1,165 @@
+package main
+
+import (
+	"math"
+	"math/rand"
+	"testing"
+
+	"gonum.org/v1/gonum/mat"
+)
+
+// frobeniusError returns ||A - WH||_F.
+func frobeniusError(A, W, H *mat.Dense) float64 {
+	approx := &mat.Dense{}
+	approx.Mul(W, H)
+
+	diff := &mat.Dense{}
+	diff.Sub(A, approx)
+	return mat.Norm(diff, 2)
+}
+
+// runUpdaterReg factorizes a synthetic low-rank matrix for a handful of
+// iterations under the given regularizer and returns the final (W, H).
+func runUpdaterReg(t *testing.T, updater NMFUpdater, reg Regularizer, A *mat.Dense, k, iters int) (*mat.Dense, *mat.Dense) {
+	t.Helper()
+	m, n := A.Dims()
+
+	w := make([]float64, m*k)
+	h := make([]float64, k*n)
+	for i := range w {
+		w[i] = rand.Float64()
+	}
+	for i := range h {
+		h[i] = rand.Float64()
+	}
+	W := mat.NewDense(m, k, w)
+	H := mat.NewDense(k, n, h)
+
+	for iter := 0; iter < iters; iter++ {
+		HGramMat := &mat.Dense{}
+		HGramMat.Mul(H, H.T())
+		HProductMat := &mat.Dense{}
+		HProductMat.Mul(A, H.T())
+		if err := updater.UpdateW(W, HGramMat, HProductMat, reg); err != nil {
+			t.Fatalf("UpdateW: %v", err)
+		}
+
+		WGramMat := &mat.Dense{}
+		WGramMat.Mul(W.T(), W)
+		WProductMat := &mat.Dense{}
+		WProductMat.Mul(W.T(), A)
+		if err := updater.UpdateH(H, WGramMat, WProductMat, reg); err != nil {
+			t.Fatalf("UpdateH: %v", err)
+		}
+	}
+
+	return W, H
+}
+
+// runUpdater factorizes a synthetic low-rank matrix for a handful of
+// iterations (unregularized) and returns the Frobenius reconstruction error.
+func runUpdater(t *testing.T, updater NMFUpdater, A *mat.Dense, k, iters int) float64 {
+	t.Helper()
+	W, H := runUpdaterReg(t, updater, Regularizer{}, A, k, iters)
+	return frobeniusError(A, W, H)
+}
+
+func TestUpdatersReduceReconstructionError(t *testing.T) {
+	rand.Seed(1)
+
+	const m, n, k = 20, 16, 3
+	wTrue := make([]float64, m*k)
+	hTrue := make([]float64, k*n)
+	for i := range wTrue {
+		wTrue[i] = rand.Float64()
+	}
+	for i := range hTrue {
+		hTrue[i] = rand.Float64()
+	}
+	A := &mat.Dense{}
+	A.Mul(mat.NewDense(m, k, wTrue), mat.NewDense(k, n, hTrue))
+
+	updaters := map[string]NMFUpdater{
+		"Multiplicative": MultiplicativeUpdater{},
+		"HALS":           HALSUpdater{},
+		"ANLSBPP":        ANLSBPPUpdater{},
+	}
+
+	for name, updater := range updaters {
+		name, updater := name, updater
+		t.Run(name, func(t *testing.T) {
+			// Reseed before each call so both start from the same random
+			// initial (W, H) - otherwise "before" and "after" are two
+			// unrelated draws, not a before/after comparison.
+			rand.Seed(42)
+			before := runUpdater(t, updater, A, k, 0)
+			rand.Seed(42)
+			after := runUpdater(t, updater, A, k, 50)
+			if math.IsNaN(after) {
+				t.Fatalf("%s: reconstruction error is NaN", name)
+			}
+			if after >= before {
+				t.Errorf("%s: reconstruction error did not improve: before=%v after=%v", name, before, after)
+			}
+		})
+	}
+}
+
+// countNearZero returns how many entries of M are within tol of 0.
+func countNearZero(M *mat.Dense, tol float64) int {
+	rows, cols := M.Dims()
+	count := 0
+	for i := 0; i < rows; i++ {
+		for j := 0; j < cols; j++ {
+			if math.Abs(M.At(i, j)) < tol {
+				count++
+			}
+		}
+	}
+	return count
+}
+
+// TestANLSBPPHandlesCollapsedComponent guards against ANLSBPPUpdater
+// crashing when a k-dimension has collapsed to all-zero (a routine
+// occurrence during NMF convergence, not an edge case): HGramMat's
+// corresponding row/column is then all-zero, making the initial
+// all-passive Gram block singular.
+func TestANLSBPPHandlesCollapsedComponent(t *testing.T) {
+	HGramMat := mat.NewDense(3, 3, []float64{
+		1, 2, 0,
+		2, 4, 0,
+		0, 0, 0,
+	})
+	HProductMatij := mat.NewDense(1, 3, []float64{1, 2, 3})
+	W := mat.NewDense(1, 3, []float64{0, 0, 0})
+
+	if err := (ANLSBPPUpdater{}).UpdateW(W, HGramMat, HProductMatij, Regularizer{}); err != nil {
+		t.Fatalf("UpdateW: %v", err)
+	}
+	if w2 := W.At(0, 2); w2 != 0 {
+		t.Errorf("collapsed component should stay at 0, got %v", w2)
+	}
+}
+
+func TestL1RegularizerIncreasesSparsity(t *testing.T) {
+	rand.Seed(2)
+
+	const m, n, k = 20, 16, 3
+	a := make([]float64, m*n)
+	for i := range a {
+		a[i] = rand.Float64()
+	}
+	A := mat.NewDense(m, n, a)
+
+	// Reseed before each call so both start from the same random initial
+	// (W, H) - otherwise "plain" and "sparse" are two unrelated draws, not
+	// a comparison of the effect of L1 alone.
+	rand.Seed(43)
+	plainW, _ := runUpdaterReg(t, MultiplicativeUpdater{}, Regularizer{}, A, k, 50)
+	rand.Seed(43)
+	sparseW, _ := runUpdaterReg(t, MultiplicativeUpdater{}, Regularizer{L1: 0.5}, A, k, 50)
+
+	if countNearZero(sparseW, 1e-6) <= countNearZero(plainW, 1e-6) {
+		t.Errorf("L1 regularization did not increase sparsity in W")
+	}
+}